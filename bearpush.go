@@ -0,0 +1,292 @@
+// Package bearpush contains the core configuration and application context
+// shared by the bearpush server and its CLI commands.
+package bearpush
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/frixuu/bearpush/queue"
+	"github.com/frixuu/bearpush/storage"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultConfigDir is where bearpush looks for its configuration when the
+// user does not pass --config-dir explicitly.
+var DefaultConfigDir = defaultConfigDir()
+
+func defaultConfigDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return filepath.Join(".", "bearpush")
+	}
+	return filepath.Join(dir, "bearpush")
+}
+
+// Config is the top-level, on-disk configuration of a bearpush instance.
+type Config struct {
+	// Path is the directory all of bearpush's state (products, artifacts,
+	// acme cache, ...) is rooted at.
+	Path string
+	// Storage is the default storage backend configuration, used by any
+	// product that does not override it.
+	Storage StorageConfig `yaml:"storage"`
+	// Queue configures how pipeline jobs are scheduled.
+	Queue QueueConfig `yaml:"queue"`
+	// Listen configures how the HTTP(S) server binds.
+	Listen ListenConfig `yaml:"listen"`
+	// Feed configures the identity used for the per-product Atom feeds.
+	Feed FeedConfig `yaml:"feed"`
+}
+
+// FeedConfig names the domain bearpush's Atom feed tag URIs are minted
+// under, per RFC 4151.
+type FeedConfig struct {
+	OriginalDomain  string `yaml:"original_domain"`
+	DomainStartDate string `yaml:"domain_start_date"`
+}
+
+// StartDate parses DomainStartDate (YYYY-MM-DD), defaulting to the Unix
+// epoch if it's unset or malformed.
+func (f FeedConfig) StartDate() time.Time {
+	t, err := time.Parse("2006-01-02", f.DomainStartDate)
+	if err != nil {
+		return time.Unix(0, 0).UTC()
+	}
+	return t
+}
+
+// ListenMode selects how bearpush binds its HTTP(S) listener.
+type ListenMode string
+
+const (
+	// ListenModeTCP is a plain, unencrypted TCP listener on Addr.
+	ListenModeTCP ListenMode = "tcp"
+	// ListenModeTLS terminates TLS itself, either from a static cert/key
+	// pair or via autocert when AutocertHosts is set.
+	ListenModeTLS ListenMode = "tls"
+	// ListenModeSystemd expects to be handed an already-open socket by
+	// systemd (LISTEN_FDS/LISTEN_PID), falling back to Addr if none was
+	// inherited.
+	ListenModeSystemd ListenMode = "systemd"
+)
+
+// ListenConfig describes how the HTTP server should bind.
+type ListenConfig struct {
+	Mode          ListenMode `yaml:"mode"`
+	Addr          string     `yaml:"addr"`
+	Cert          string     `yaml:"cert"`
+	Key           string     `yaml:"key"`
+	AutocertHosts []string   `yaml:"autocert_hosts"`
+}
+
+// QueueConfig configures the job queue backend shared by every product.
+type QueueConfig struct {
+	// RedisAddr, if set, switches the queue from its single-node,
+	// in-memory fallback to an asynq queue backed by this Redis instance.
+	RedisAddr string `yaml:"redis_addr"`
+}
+
+// RetentionConfig is the YAML-friendly form of storage.Retention: it allows
+// a human-readable duration string (e.g. "30d") for MaxAge.
+type RetentionConfig struct {
+	KeepLast int    `yaml:"keep_last"`
+	MaxAge   string `yaml:"max_age"`
+}
+
+// Resolve converts the config into the storage.Retention the backends
+// actually consume.
+func (r RetentionConfig) Resolve() storage.Retention {
+	maxAge, _ := parseDuration(r.MaxAge)
+	return storage.Retention{KeepLast: r.KeepLast, MaxAge: maxAge}
+}
+
+// parseDuration extends time.ParseDuration with a "d" (day) unit, since
+// retention windows are usually expressed in days.
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// StorageConfig selects and configures an artifact storage backend.
+type StorageConfig struct {
+	// Backend is either "local" (the default) or "s3".
+	Backend   string          `yaml:"backend"`
+	Bucket    string          `yaml:"bucket"`
+	Endpoint  string          `yaml:"endpoint"`
+	AccessKey string          `yaml:"access_key"`
+	SecretKey string          `yaml:"secret_key"`
+	UseSSL    bool            `yaml:"use_ssl"`
+	Retention RetentionConfig `yaml:"retention"`
+}
+
+// Build constructs the storage.Storage backend described by cfg, rooting
+// any local storage under config.Path/artifacts.
+func (cfg StorageConfig) Build(config *Config) (storage.Storage, error) {
+	switch cfg.Backend {
+	case "s3":
+		return storage.NewS3(storage.S3Config{
+			Endpoint:  cfg.Endpoint,
+			Bucket:    cfg.Bucket,
+			AccessKey: cfg.AccessKey,
+			SecretKey: cfg.SecretKey,
+			UseSSL:    cfg.UseSSL,
+			Retention: cfg.Retention.Resolve(),
+		})
+	case "", "local":
+		return storage.NewLocalFS(filepath.Join(config.Path, "artifacts"), cfg.Retention.Resolve())
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+}
+
+// LoadConfig resolves the configuration directory at path, creating it if
+// it does not exist yet, and parses config.yml within it if present.
+func LoadConfig(path string) (*Config, error) {
+	if err := os.MkdirAll(path, 0740); err != nil && !os.IsExist(err) {
+		return nil, err
+	}
+
+	config := &Config{Path: path}
+	data, err := os.ReadFile(filepath.Join(path, "config.yml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return config, nil
+		}
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("parsing config.yml: %w", err)
+	}
+	config.Path = path
+	return config, nil
+}
+
+// TokenStrategy decides how an upload request is authenticated.
+type TokenStrategy string
+
+const (
+	// TokenStrategyNone disables authentication for a product.
+	TokenStrategyNone TokenStrategy = "none"
+	// TokenStrategyStatic checks the request against a single shared secret.
+	TokenStrategyStatic TokenStrategy = "static"
+)
+
+// TokenSettings configures how a product's upload endpoint is protected.
+type TokenSettings struct {
+	Strategy TokenStrategy `yaml:"strategy"`
+	Token    string        `yaml:"token"`
+}
+
+// PipelineConfig is the YAML-friendly form of queue.Settings: durations are
+// human-readable strings (e.g. "30s") rather than time.Duration.
+type PipelineConfig struct {
+	MaxRetries   int    `yaml:"max_retries"`
+	RetryBackoff string `yaml:"retry_backoff"`
+	Timeout      string `yaml:"timeout"`
+	Concurrency  int    `yaml:"concurrency"`
+}
+
+// Resolve converts cfg into the queue.Settings the job queue actually
+// consumes, filling in unset fields from queue.DefaultSettings.
+func (cfg PipelineConfig) Resolve() queue.Settings {
+	settings := queue.DefaultSettings
+	if cfg.MaxRetries > 0 {
+		settings.MaxRetries = cfg.MaxRetries
+	}
+	if d, err := parseDuration(cfg.RetryBackoff); err == nil && d > 0 {
+		settings.RetryBackoff = d
+	}
+	if d, err := parseDuration(cfg.Timeout); err == nil && d > 0 {
+		settings.Timeout = d
+	}
+	if cfg.Concurrency > 0 {
+		settings.Concurrency = cfg.Concurrency
+	}
+	return settings
+}
+
+// Product is a single pipeline bearpush knows how to accept artifacts for.
+type Product struct {
+	Name          string         `yaml:"-"`
+	Script        string         `yaml:"script"`
+	TokenSettings TokenSettings  `yaml:"token"`
+	Storage       *StorageConfig `yaml:"storage"`
+	Pipeline      PipelineConfig `yaml:"pipeline"`
+	// MaxArtifactSize, in bytes, rejects uploads declaring a larger
+	// Upload-Length over the tus endpoint. 0 means no limit.
+	MaxArtifactSize int64 `yaml:"max_artifact_size"`
+}
+
+// StorageBackend returns the storage backend to use for this product, falling
+// back to the instance-wide default when the product doesn't override it.
+func (p Product) StorageBackend(config *Config) (storage.Storage, error) {
+	if p.Storage != nil {
+		return p.Storage.Build(config)
+	}
+	return config.Storage.Build(config)
+}
+
+// AppContext is the in-memory, loaded view of a Config: every product
+// definition found on disk, ready to be served.
+type AppContext struct {
+	Config   *Config
+	Products map[string]Product
+}
+
+// ContextFromConfig loads every product YAML file under config.Path/products
+// into an AppContext.
+func ContextFromConfig(config *Config) (*AppContext, error) {
+	ctx := &AppContext{
+		Config:   config,
+		Products: make(map[string]Product),
+	}
+
+	productsDir := filepath.Join(config.Path, "products")
+	entries, err := os.ReadDir(productsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ctx, nil
+		}
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".yml" {
+			continue
+		}
+
+		name := entry.Name()[:len(entry.Name())-len(".yml")]
+		data, err := os.ReadFile(filepath.Join(productsDir, entry.Name()))
+		if err != nil {
+			zap.S().Warnf("Cannot read product file %s: %s", entry.Name(), err)
+			continue
+		}
+
+		var p Product
+		if err := yaml.Unmarshal(data, &p); err != nil {
+			zap.S().Warnf("Cannot parse product file %s: %s", entry.Name(), err)
+			continue
+		}
+
+		p.Name = name
+		ctx.Products[name] = p
+	}
+
+	return ctx, nil
+}
@@ -0,0 +1,41 @@
+package bearpush
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"30s", 30 * time.Second, false},
+		{"5m", 5 * time.Minute, false},
+		{"7d", 7 * 24 * time.Hour, false},
+		{"nope", 0, true},
+	}
+
+	for _, c := range cases {
+		got, err := parseDuration(c.in)
+		if (err != nil) != c.wantErr {
+			t.Errorf("parseDuration(%q) error = %v, wantErr %v", c.in, err, c.wantErr)
+			continue
+		}
+		if err == nil && got != c.want {
+			t.Errorf("parseDuration(%q) = %v, want %v", c.in, got, c.want)
+		}
+	}
+}
+
+func TestRetentionConfigResolve(t *testing.T) {
+	r := RetentionConfig{KeepLast: 3, MaxAge: "2d"}.Resolve()
+	if r.KeepLast != 3 {
+		t.Errorf("KeepLast = %d, want 3", r.KeepLast)
+	}
+	if r.MaxAge != 48*time.Hour {
+		t.Errorf("MaxAge = %v, want 48h", r.MaxAge)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/frixuu/bearpush"
+	"github.com/frixuu/bearpush/internal/atom"
+	"github.com/frixuu/bearpush/server"
+	"github.com/gin-gonic/gin"
+)
+
+// serveFeed renders GET /v1/feed/:product.atom: an Atom feed of a
+// product's successfully stored releases.
+//
+// The route param captures the whole "<product>.atom" segment (gin params
+// can't split on a literal dot), so token validation has to happen here
+// rather than via the usual server.ValidateToken middleware.
+func serveFeed(c *gin.Context, appContext *bearpush.AppContext, config *bearpush.Config) {
+	product := strings.TrimSuffix(c.Param("productfile"), ".atom")
+	p, ok := appContext.Products[product]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   4,
+			"message": "Resource does not exist.",
+		})
+		return
+	}
+
+	if err := server.CheckToken(p, c.GetHeader("X-Bearpush-Token")); err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error":   1,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	backend, err := p.StorageBackend(config)
+	if err != nil {
+		c.String(http.StatusInternalServerError,
+			"Could not set up storage for this product. Check logs for details.")
+		return
+	}
+
+	refs, err := backend.List(product, "", 0)
+	if err != nil {
+		c.String(http.StatusInternalServerError,
+			"Could not list stored artifacts. Check logs for details.")
+		return
+	}
+
+	updated := config.Feed.StartDate()
+	if len(refs) > 0 {
+		updated = refs[0].UploadedAt
+	}
+
+	if ifModifiedSince := c.GetHeader("If-Modified-Since"); ifModifiedSince != "" {
+		if since, err := http.ParseTime(ifModifiedSince); err == nil && !updated.After(since) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+
+	domain := config.Feed.OriginalDomain
+	domainStartDate := config.Feed.StartDate()
+
+	feed := atom.NewFeed(
+		fmt.Sprintf("%s releases", product),
+		atom.TagURI(domain, domainStartDate, product),
+		updated,
+	)
+
+	for _, ref := range refs {
+		if ref.Version == "" {
+			// Artifacts stored before version metadata was persisted
+			// (storage.ObjectRef.Version) don't have enough information
+			// for a meaningful, stable entry id; skip rather than publish
+			// duplicates.
+			continue
+		}
+		feed.Entries = append(feed.Entries, atom.Entry{
+			ID:      atom.TagURI(domain, domainStartDate, fmt.Sprintf("%s/%s", product, ref.Version)),
+			Title:   fmt.Sprintf("%s %s", product, ref.Version),
+			Updated: ref.UploadedAt,
+			Link: atom.Link{
+				Rel:    "enclosure",
+				Href:   fmt.Sprintf("/v1/download/%s/%s", product, ref.Version),
+				Length: ref.Size,
+				Type:   "application/octet-stream",
+			},
+			Content: fmt.Sprintf("Artifact %s (sha256 %s, %d bytes) was uploaded successfully.",
+				ref.Version, ref.SHA256, ref.Size),
+		})
+	}
+
+	body, err := feed.Render()
+	if err != nil {
+		c.String(http.StatusInternalServerError,
+			"Could not render feed. Check logs for details.")
+		return
+	}
+
+	c.Header("Last-Modified", updated.UTC().Format(http.TimeFormat))
+	c.Data(http.StatusOK, "application/atom+xml; charset=utf-8", body)
+}
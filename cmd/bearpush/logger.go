@@ -0,0 +1,12 @@
+package main
+
+import "go.uber.org/zap"
+
+// CreateLogger builds the process-wide logger used by every command.
+func CreateLogger() *zap.SugaredLogger {
+	logger, err := zap.NewProduction()
+	if err != nil {
+		panic(err)
+	}
+	return logger.Sugar()
+}
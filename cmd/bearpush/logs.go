@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/frixuu/bearpush/queue"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+var logsUpgrader = websocket.Upgrader{
+	// Artifact uploads and their job logs come from the same trusted CI
+	// clients that already hold a product token, so any origin is fine.
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// streamJobLogs writes a job's output as Server-Sent Events. With
+// follow=1 it keeps the connection open and relays new lines as the
+// pipeline produces them; otherwise it replays what's buffered so far and
+// closes.
+func streamJobLogs(c *gin.Context, jobManager *queue.Manager, id string, follow bool) {
+	hub, running := queue.Hubs.Get(id)
+	if !running {
+		job, ok, err := jobManager.Get(id)
+		if err != nil || !ok {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   4,
+				"message": "Resource does not exist.",
+			})
+			return
+		}
+		c.Header("Content-Type", "text/event-stream")
+		writeSSEFrame(c.Writer, queue.Frame{Event: "done", Data: string(job.Status)})
+		return
+	}
+
+	ch, unsubscribe := hub.Subscribe()
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	c.Stream(func(w io.Writer) bool {
+		if !follow {
+			// Drain only what's already buffered (the replayed backlog,
+			// plus anything published before we got here) without
+			// blocking on frames that haven't been produced yet.
+			select {
+			case frame, ok := <-ch:
+				if !ok {
+					return false
+				}
+				writeSSEFrame(w, frame)
+				return true
+			default:
+				return false
+			}
+		}
+
+		select {
+		case frame, ok := <-ch:
+			if !ok {
+				return false
+			}
+			writeSSEFrame(w, frame)
+			return frame.Event != "done"
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+func writeSSEFrame(w io.Writer, frame queue.Frame) {
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", frame.Event, frame.Data)
+}
+
+// streamJobLogsWS is the WebSocket equivalent of streamJobLogs: always
+// follows, since a closed connection is the client's way of unsubscribing.
+func streamJobLogsWS(c *gin.Context, jobManager *queue.Manager, id string) {
+	hub, running := queue.Hubs.Get(id)
+	if !running {
+		if _, ok, err := jobManager.Get(id); err != nil || !ok {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   4,
+				"message": "Resource does not exist.",
+			})
+			return
+		}
+	}
+
+	conn, err := logsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	if hub == nil {
+		return
+	}
+
+	ch, unsubscribe := hub.Subscribe()
+	defer unsubscribe()
+
+	for frame := range ch {
+		if err := conn.WriteJSON(frame); err != nil {
+			return
+		}
+		if frame.Event == "done" {
+			return
+		}
+	}
+}
@@ -2,26 +2,61 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net/http"
 	"os"
-	"os/exec"
-	"path"
 	"path/filepath"
 	"time"
 
 	"github.com/frixuu/bearpush"
 	"github.com/frixuu/bearpush/config/templates"
 	"github.com/frixuu/bearpush/internal/util"
+	"github.com/frixuu/bearpush/queue"
 	"github.com/frixuu/bearpush/server"
+	"github.com/frixuu/bearpush/storage"
 	ginzap "github.com/gin-contrib/zap"
 	"github.com/gin-gonic/gin"
 	"github.com/urfave/cli/v2"
 	"go.uber.org/zap"
 )
 
+// validateJobToken returns a middleware that protects job-scoped routes
+// (/v1/jobs/:id/...) with the same token check as the product's other
+// endpoints. Unlike server.ValidateToken, the product isn't in the route
+// itself, so it's looked up from the job the :id param refers to.
+func validateJobToken(appContext *bearpush.AppContext, jobManager *queue.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		job, ok, err := jobManager.Get(c.Param("id"))
+		if err != nil || !ok {
+			// Let the handler itself report the missing/unreadable job so
+			// the error message stays consistent across routes.
+			c.Next()
+			return
+		}
+
+		p, ok := appContext.Products[job.Product]
+		if !ok {
+			c.Next()
+			return
+		}
+
+		if err := server.CheckToken(p, c.GetHeader("X-Bearpush-Token")); err != nil {
+			status := http.StatusUnauthorized
+			if errors.Is(err, server.ErrUnknownTokenStrategy) {
+				status = http.StatusInternalServerError
+			}
+			c.AbortWithStatusJSON(status, gin.H{
+				"error":   1,
+				"message": err.Error(),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
 func main() {
 
 	logger := CreateLogger()
@@ -113,6 +148,18 @@ func main() {
 				logger.Infof("Loaded product %s, token strategy %v", name, p.TokenSettings.Strategy)
 			}
 
+			jobStore, err := queue.NewFileStore(filepath.Join(config.Path, "jobs"))
+			if err != nil {
+				logger.Errorf("Cannot set up job store: %s\n", err)
+				return err
+			}
+
+			jobManager, err := queue.NewManager(jobStore, pipelineRunner(appContext, config, logger), config.Queue.RedisAddr, logger)
+			if err != nil {
+				logger.Errorf("Cannot set up job queue: %s\n", err)
+				return err
+			}
+
 			gin.SetMode(gin.ReleaseMode)
 			gin.DefaultWriter = io.Discard
 			gin.DefaultErrorWriter = io.Discard
@@ -145,6 +192,14 @@ func main() {
 						return
 					}
 
+					backend, err := p.StorageBackend(config)
+					if err != nil {
+						logger.Errorf("Cannot set up storage backend for %s: %s", product, err)
+						c.String(http.StatusInternalServerError,
+							"Could not set up storage for this product. Check logs for details.")
+						return
+					}
+
 					file, err := c.FormFile("artifact")
 					if err != nil {
 						logger.Warn(err)
@@ -152,79 +207,226 @@ func main() {
 						return
 					}
 
-					tempDir, err := ioutil.TempDir("", "bearpush-")
+					src, err := file.Open()
 					if err != nil {
-						logger.Error(err)
+						logger.Error("Cannot open uploaded artifact: %s", err)
 						c.String(http.StatusInternalServerError,
-							"Could not create a temporary directory for artifact. Check logs for details.")
+							"Could not read the uploaded artifact. Check logs for details.")
 						return
 					}
-					defer util.TryRemoveDir(tempDir)
+					defer src.Close()
 
-					artifactPath := path.Join(tempDir, "artifact")
-					err = c.SaveUploadedFile(file, artifactPath)
+					version := c.PostForm("version")
+					if version == "" {
+						version = time.Now().UTC().Format("20060102T150405Z")
+					}
+
+					ref, err := backend.Put(product, version, src, file.Size)
 					if err != nil {
-						logger.Error("Cannot save artifact: %s", err)
+						logger.Error("Cannot store artifact: %s", err)
 						c.String(http.StatusInternalServerError,
 							"Could not save the uploaded artifact. Check logs for details.")
 						return
 					}
 
-					if p.Script != "" {
-						cmd := exec.Command(p.Script)
-						cmd.Env = append(os.Environ(),
-							fmt.Sprintf("ARTIFACT_PATH=%s", artifactPath),
-						)
+					if p.Script == "" {
+						c.String(http.StatusOK,
+							fmt.Sprintf("Artifact for product %s stored successfully.", product))
+						return
+					}
 
-						stdoutPipe, err := cmd.StdoutPipe()
-						if err != nil {
-							logger.Errorf("Cannot grab stdout pipe: %s\n", err)
-						}
+					job, err := jobManager.Enqueue(product, ref, p.Pipeline.Resolve())
+					if err != nil {
+						logger.Errorf("Cannot enqueue job for %s: %s", product, err)
+						c.String(http.StatusInternalServerError,
+							"Could not schedule the pipeline run. Check logs for details.")
+						return
+					}
 
-						stderrPipe, err := cmd.StderrPipe()
-						if err != nil {
-							logger.Errorf("Cannot grab stderr pipe: %s\n", err)
-						}
+					c.JSON(http.StatusAccepted, gin.H{
+						"job_id": job.ID,
+					})
+				})
 
-						if err := cmd.Start(); err != nil {
-							logger.Errorf("Cannot start: %s\n", err)
-						}
+				v1.GET("/jobs/:id", validateJobToken(appContext, jobManager), func(c *gin.Context) {
+					job, ok, err := jobManager.Get(c.Param("id"))
+					if err != nil {
+						logger.Errorf("Cannot load job %s: %s", c.Param("id"), err)
+						c.String(http.StatusInternalServerError,
+							"Could not load job. Check logs for details.")
+						return
+					}
+					if !ok {
+						c.JSON(http.StatusNotFound, gin.H{
+							"error":   4,
+							"message": "Resource does not exist.",
+						})
+						return
+					}
 
-						_, err = io.ReadAll(stdoutPipe)
-						if err != nil {
-							logger.Errorf("Cannot read stdout: %s\n", err)
-						}
+					c.JSON(http.StatusOK, job)
+				})
 
-						_, err = io.ReadAll(stderrPipe)
-						if err != nil {
-							logger.Errorf("Cannot read stderr: %s\n", err)
-						}
+				v1.POST("/jobs/:id/retry", validateJobToken(appContext, jobManager), func(c *gin.Context) {
+					id := c.Param("id")
+					existing, ok, err := jobManager.Get(id)
+					if err != nil {
+						logger.Errorf("Cannot load job %s: %s", id, err)
+						c.String(http.StatusInternalServerError,
+							"Could not load job. Check logs for details.")
+						return
+					}
+					if !ok {
+						c.JSON(http.StatusNotFound, gin.H{
+							"error":   4,
+							"message": "Resource does not exist.",
+						})
+						return
+					}
+
+					p, ok := appContext.Products[existing.Product]
+					if !ok {
+						c.JSON(http.StatusBadRequest, gin.H{
+							"error":   4,
+							"message": "Resource does not exist.",
+						})
+						return
+					}
+
+					job, err := jobManager.Retry(id, p.Pipeline.Resolve())
+					if err != nil {
+						logger.Errorf("Cannot retry job %s: %s", id, err)
+						c.String(http.StatusInternalServerError,
+							"Could not retry job. Check logs for details.")
+						return
+					}
 
-						if err := cmd.Wait(); err != nil {
-							logger.Errorf("Command failed: %s\n", err)
-							c.JSON(http.StatusUnprocessableEntity, gin.H{
-								"error":   8,
-								"message": "Pipeline associated with resource errored.",
-							})
-							return
+					c.JSON(http.StatusAccepted, gin.H{
+						"job_id": job.ID,
+					})
+				})
+
+				v1.GET("/jobs/:id/logs", validateJobToken(appContext, jobManager), func(c *gin.Context) {
+					streamJobLogs(c, jobManager, c.Param("id"), c.Query("follow") == "1")
+				})
+
+				v1.GET("/jobs/:id/logs/ws", validateJobToken(appContext, jobManager), func(c *gin.Context) {
+					streamJobLogsWS(c, jobManager, c.Param("id"))
+				})
+
+				v1.GET("/versions/:product", server.ValidateToken(appContext), func(c *gin.Context) {
+					product := c.Param("product")
+					p, ok := appContext.Products[product]
+					if !ok {
+						c.JSON(http.StatusBadRequest, gin.H{
+							"error":   4,
+							"message": "Resource does not exist.",
+						})
+						return
+					}
+
+					backend, err := p.StorageBackend(config)
+					if err != nil {
+						logger.Errorf("Cannot set up storage backend for %s: %s", product, err)
+						c.String(http.StatusInternalServerError,
+							"Could not set up storage for this product. Check logs for details.")
+						return
+					}
+
+					refs, err := backend.List(product, "", 0)
+					if err != nil {
+						logger.Errorf("Cannot list versions for %s: %s", product, err)
+						c.String(http.StatusInternalServerError,
+							"Could not list stored artifacts. Check logs for details.")
+						return
+					}
+
+					c.JSON(http.StatusOK, refs)
+				})
+
+				v1.GET("/download/:product/:version", server.ValidateToken(appContext), func(c *gin.Context) {
+					product := c.Param("product")
+					version := c.Param("version")
+					p, ok := appContext.Products[product]
+					if !ok {
+						c.JSON(http.StatusBadRequest, gin.H{
+							"error":   4,
+							"message": "Resource does not exist.",
+						})
+						return
+					}
+
+					backend, err := p.StorageBackend(config)
+					if err != nil {
+						logger.Errorf("Cannot set up storage backend for %s: %s", product, err)
+						c.String(http.StatusInternalServerError,
+							"Could not set up storage for this product. Check logs for details.")
+						return
+					}
+
+					// List filters by prefix, not equality (e.g. requesting
+					// "1.0" would otherwise match a newer "1.0.5"), so scan
+					// its matches for the one whose Version is exactly what
+					// was asked for.
+					candidates, err := backend.List(product, version, 0)
+					if err != nil {
+						c.JSON(http.StatusNotFound, gin.H{
+							"error":   4,
+							"message": "No artifact found for that version.",
+						})
+						return
+					}
+
+					var refs []storage.ObjectRef
+					for _, ref := range candidates {
+						if ref.Version == version {
+							refs = []storage.ObjectRef{ref}
+							break
 						}
 					}
+					if len(refs) == 0 {
+						c.JSON(http.StatusNotFound, gin.H{
+							"error":   4,
+							"message": "No artifact found for that version.",
+						})
+						return
+					}
+
+					reader, err := backend.Get(refs[0])
+					if err != nil {
+						logger.Errorf("Cannot read artifact %s/%s: %s", product, version, err)
+						c.String(http.StatusInternalServerError,
+							"Could not read the stored artifact. Check logs for details.")
+						return
+					}
+					defer reader.Close()
+
+					c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", version))
+					c.DataFromReader(http.StatusOK, refs[0].Size, "application/octet-stream", reader, nil)
+				})
 
-					c.String(http.StatusOK,
-						fmt.Sprintf("Artifact for product %s processed successfully.", product))
+				v1.GET("/feed/:productfile", func(c *gin.Context) {
+					serveFeed(c, appContext, config)
 				})
 			}
 
-			port := server.DeterminePort()
-			logger.Info("The server will bind to ", port)
+			if err := registerTusRoutes(v1, appContext, config, jobManager, logger); err != nil {
+				logger.Errorf("Cannot set up tus uploads: %s\n", err)
+				return err
+			}
+
+			listenConfig := config.Listen
+			if listenConfig.Addr == "" {
+				listenConfig.Addr = server.DeterminePort()
+			}
+			logger.Info("The server will bind to ", listenConfig.Addr)
 
 			srv := &http.Server{
-				Addr:    port,
 				Handler: router,
 			}
 
 			// Listen in a goroutine
-			go server.Start(srv, logger.Desugar())
+			go server.Start(srv, listenConfig, config, logger.Desugar())
 
 			util.WaitForInterrupt()
 			logger.Info("Shutting down the server")
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/frixuu/bearpush"
+	"github.com/frixuu/bearpush/queue"
+	"github.com/frixuu/bearpush/storage"
+	"go.uber.org/zap"
+)
+
+// pipelineRunner builds the queue.Runner that actually executes a product's
+// pipeline script against a job's stored artifact.
+func pipelineRunner(appContext *bearpush.AppContext, config *bearpush.Config, logger *zap.SugaredLogger) queue.Runner {
+	return func(job queue.Job, settings queue.Settings, out *queue.OutputBuffer) error {
+		p, ok := appContext.Products[job.Product]
+		if !ok {
+			return fmt.Errorf("product %s no longer exists", job.Product)
+		}
+
+		ctx := context.Background()
+		var cancel context.CancelFunc
+		if settings.Timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, settings.Timeout)
+			defer cancel()
+		}
+
+		cmd := exec.CommandContext(ctx, p.Script)
+		cmd.Env = append(os.Environ(),
+			fmt.Sprintf("BEARPUSH_PRODUCT=%s", job.Product),
+			fmt.Sprintf("BEARPUSH_VERSION=%s", job.ArtifactRef.Version),
+			fmt.Sprintf("BEARPUSH_SHA256=%s", job.ArtifactRef.SHA256),
+		)
+
+		backend, err := p.StorageBackend(config)
+		if err != nil {
+			return fmt.Errorf("setting up storage backend: %w", err)
+		}
+		if locator, ok := backend.(storage.ScriptLocator); ok {
+			if location, err := locator.LocateForScript(job.ArtifactRef); err == nil {
+				cmd.Env = append(cmd.Env, fmt.Sprintf("ARTIFACT_PATH=%s", location))
+			} else {
+				logger.Warnf("Cannot locate artifact for script: %s", err)
+			}
+		}
+
+		hub := queue.Hubs.Register(job.ID)
+		defer queue.Hubs.Release(job.ID)
+
+		stdoutPipe, err := cmd.StdoutPipe()
+		if err != nil {
+			return fmt.Errorf("grabbing stdout pipe: %w", err)
+		}
+		stderrPipe, err := cmd.StderrPipe()
+		if err != nil {
+			return fmt.Errorf("grabbing stderr pipe: %w", err)
+		}
+
+		if err := cmd.Start(); err != nil {
+			hub.Done("error")
+			return fmt.Errorf("starting pipeline script: %w", err)
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go scanInto(stdoutPipe, out, hub, &wg)
+		go scanInto(stderrPipe, out, hub, &wg)
+		wg.Wait()
+
+		err = cmd.Wait()
+		if err != nil {
+			hub.Done("failed")
+			return fmt.Errorf("pipeline script failed: %w", err)
+		}
+		hub.Done("succeeded")
+		return nil
+	}
+}
+
+// scanInto reads r line by line, recording each line into out (the job's
+// buffered tail) and publishing it live to hub's subscribers.
+func scanInto(r io.Reader, out *queue.OutputBuffer, hub *queue.Hub, wg *sync.WaitGroup) {
+	defer wg.Done()
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		fmt.Fprintln(out, line)
+		hub.Publish(line)
+	}
+}
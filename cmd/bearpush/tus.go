@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/frixuu/bearpush"
+	"github.com/frixuu/bearpush/queue"
+	"github.com/frixuu/bearpush/server"
+	"github.com/frixuu/bearpush/storage"
+	"github.com/frixuu/bearpush/tus"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// tusUploadTTL is how long after its creation an in-progress tus upload
+// expires and becomes eligible to be garbage collected. PATCH requests do
+// not extend it.
+const tusUploadTTL = 24 * time.Hour
+
+// registerTusRoutes wires the tus.io resumable upload protocol onto
+// /v1/tus/:product, finishing a completed upload exactly as the classic
+// multipart /v1/upload/:product endpoint does: store it, then enqueue a
+// pipeline job if the product has a script configured.
+func registerTusRoutes(v1 *gin.RouterGroup, appContext *bearpush.AppContext, config *bearpush.Config, jobManager *queue.Manager, logger *zap.SugaredLogger) error {
+	store, err := tus.NewStore(filepath.Join(os.TempDir(), "bearpush-tus"))
+	if err != nil {
+		return fmt.Errorf("setting up tus upload store: %w", err)
+	}
+
+	exists := func(product string) bool {
+		_, ok := appContext.Products[product]
+		return ok
+	}
+
+	maxSize := func(product string) int64 {
+		if p, ok := appContext.Products[product]; ok {
+			return p.MaxArtifactSize
+		}
+		return 0
+	}
+
+	onComplete := func(c *gin.Context, product string, upload *tus.Upload, partPath string) error {
+		p, ok := appContext.Products[product]
+		if !ok {
+			return fmt.Errorf("product %s no longer exists", product)
+		}
+
+		backend, err := p.StorageBackend(config)
+		if err != nil {
+			return fmt.Errorf("setting up storage backend: %w", err)
+		}
+
+		version := upload.Metadata["version"]
+		if version == "" {
+			version = time.Now().UTC().Format("20060102T150405Z")
+		}
+
+		var ref storage.ObjectRef
+		if adopter, ok := backend.(storage.Adopter); ok {
+			// Move the already-complete part file into place instead of
+			// streaming it through Put, which would mean a second full
+			// copy of what's typically the largest
+			// artifact size tus is used for.
+			ref, err = adopter.Adopt(product, version, partPath, upload.Length)
+			if err != nil {
+				return fmt.Errorf("storing artifact: %w", err)
+			}
+		} else {
+			f, err := os.Open(partPath)
+			if err != nil {
+				return fmt.Errorf("opening finished upload: %w", err)
+			}
+			defer f.Close()
+
+			ref, err = backend.Put(product, version, f, upload.Length)
+			if err != nil {
+				return fmt.Errorf("storing artifact: %w", err)
+			}
+		}
+
+		if p.Script == "" {
+			return nil
+		}
+
+		if _, err := jobManager.Enqueue(product, ref, p.Pipeline.Resolve()); err != nil {
+			return fmt.Errorf("enqueuing pipeline job: %w", err)
+		}
+		return nil
+	}
+
+	group := v1.Group("/tus/:product")
+	group.Use(server.ValidateToken(appContext))
+	{
+		group.OPTIONS("", tus.OptionsHandler)
+		group.POST("", tus.CreateHandler(store, tusUploadTTL, exists, maxSize))
+		group.HEAD("/:id", tus.HeadHandler(store))
+		group.PATCH("/:id", tus.PatchHandler(store, onComplete))
+		group.DELETE("/:id", tus.DeleteHandler(store))
+	}
+
+	return nil
+}
@@ -0,0 +1,15 @@
+// Package templates generates the boilerplate YAML scaffolded by
+// `bearpush product new`.
+package templates
+
+import "fmt"
+
+// GenerateProductFile returns the starter YAML configuration for a newly
+// created product named name.
+func GenerateProductFile(name string) string {
+	return fmt.Sprintf(`# Configuration for product %q.
+script: ""
+token:
+  strategy: none
+`, name)
+}
@@ -0,0 +1,63 @@
+// Package atom renders RFC 4287 Atom 1.0 feeds, used to let downstream
+// automation discover newly published artifacts without polling the
+// versions API.
+package atom
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+// Feed is the root <feed> element.
+type Feed struct {
+	XMLName xml.Name  `xml:"feed"`
+	Xmlns   string    `xml:"xmlns,attr"`
+	Title   string    `xml:"title"`
+	ID      string    `xml:"id"`
+	Updated time.Time `xml:"updated"`
+	Entries []Entry   `xml:"entry"`
+}
+
+// Entry is a single <entry>, one per stored artifact.
+type Entry struct {
+	ID        string    `xml:"id"`
+	Title     string    `xml:"title"`
+	Updated   time.Time `xml:"updated"`
+	Link      Link      `xml:"link"`
+	Content   string    `xml:"content"`
+}
+
+// Link is the Atom <link> pointing at the downloadable artifact.
+type Link struct {
+	Rel    string `xml:"rel,attr"`
+	Href   string `xml:"href,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// NewFeed builds an empty feed with the given title and id, ready to have
+// entries appended.
+func NewFeed(title, id string, updated time.Time) *Feed {
+	return &Feed{
+		Xmlns:   "http://www.w3.org/2005/Atom",
+		Title:   title,
+		ID:      id,
+		Updated: updated,
+	}
+}
+
+// Render serializes the feed as an Atom 1.0 XML document.
+func (f *Feed) Render() ([]byte, error) {
+	out, err := xml.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// TagURI builds an RFC 4151 tag URI identifying a resource owned by
+// domain since domainStartDate, e.g. tag:bearpush.dev,2020-01-01:myapp/42.
+func TagURI(domain string, domainStartDate time.Time, specific string) string {
+	return fmt.Sprintf("tag:%s,%s:%s", domain, domainStartDate.Format("2006-01-02"), specific)
+}
@@ -0,0 +1,37 @@
+package atom
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTagURI(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	got := TagURI("bearpush.dev", start, "myapp/42")
+	want := "tag:bearpush.dev,2020-01-01:myapp/42"
+	if got != want {
+		t.Fatalf("TagURI = %q, want %q", got, want)
+	}
+}
+
+func TestFeedRenderIncludesEntries(t *testing.T) {
+	updated := time.Date(2026, 7, 28, 12, 0, 0, 0, time.UTC)
+	feed := NewFeed("demo releases", "tag:bearpush.dev,2020-01-01:demo", updated)
+	feed.Entries = append(feed.Entries, Entry{
+		ID:    "tag:bearpush.dev,2020-01-01:demo/1.0.0",
+		Title: "demo 1.0.0",
+		Link:  Link{Rel: "enclosure", Href: "/v1/download/demo/1.0.0"},
+	})
+
+	body, err := feed.Render()
+	if err != nil {
+		t.Fatalf("Render: %s", err)
+	}
+
+	for _, want := range []string{"demo releases", "demo/1.0.0", "/v1/download/demo/1.0.0"} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("rendered feed missing %q:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+// Package util contains small helpers shared across the bearpush codebase
+// that don't have a better home of their own.
+package util
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap"
+)
+
+// TryRemoveDir removes the directory at path, logging (but not panicking on)
+// any error. It's meant to be used in defer statements cleaning up temporary
+// working directories.
+func TryRemoveDir(path string) {
+	if err := os.RemoveAll(path); err != nil {
+		zap.S().Warnf("Could not remove temporary directory %s: %s", path, err)
+	}
+}
+
+// WaitForInterrupt blocks until the process receives SIGINT or SIGTERM.
+func WaitForInterrupt() {
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+}
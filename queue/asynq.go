@@ -0,0 +1,85 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+	"go.uber.org/zap"
+)
+
+const taskTypeRunPipeline = "pipeline:run"
+
+// asynqBackend schedules jobs onto a Redis-backed asynq queue, so multiple
+// bearpush nodes can share the queued work. Each node runs one asynq server
+// with 20 workers shared by all products; per-product Settings.Concurrency
+// is not applied.
+type asynqBackend struct {
+	client  *asynq.Client
+	manager *Manager
+	logger  *zap.SugaredLogger
+}
+
+type asynqPayload struct {
+	Job      Job
+	Settings Settings
+}
+
+func newAsynqBackend(redisAddr string, manager *Manager, logger *zap.SugaredLogger) (*asynqBackend, error) {
+	b := &asynqBackend{
+		client:  asynq.NewClient(asynq.RedisClientOpt{Addr: redisAddr}),
+		manager: manager,
+		logger:  logger,
+	}
+
+	srv := asynq.NewServer(asynq.RedisClientOpt{Addr: redisAddr}, asynq.Config{
+		Concurrency: 20,
+	})
+	mux := asynq.NewServeMux()
+	mux.HandleFunc(taskTypeRunPipeline, b.handle)
+
+	go func() {
+		if err := srv.Run(mux); err != nil {
+			logger.Fatalf("asynq server stopped: %s", err)
+		}
+	}()
+
+	return b, nil
+}
+
+func (b *asynqBackend) enqueue(job Job, settings Settings) {
+	payload, err := json.Marshal(asynqPayload{Job: job, Settings: settings})
+	if err != nil {
+		b.logger.Errorf("Cannot marshal job %s for asynq: %s", job.ID, err)
+		return
+	}
+
+	task := asynq.NewTask(taskTypeRunPipeline, payload)
+	opts := []asynq.Option{asynq.MaxRetry(settings.MaxRetries)}
+	if settings.Timeout > 0 {
+		opts = append(opts, asynq.Timeout(settings.Timeout))
+	}
+
+	if _, err := b.client.Enqueue(task, opts...); err != nil {
+		b.logger.Errorf("Cannot enqueue job %s: %s", job.ID, err)
+	}
+}
+
+func (b *asynqBackend) handle(ctx context.Context, task *asynq.Task) error {
+	var payload asynqPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("unmarshaling job payload: %w", err)
+	}
+
+	// The task payload is fixed at enqueue time, so payload.Job.Attempt is
+	// always 0 here even on asynq's own internal retries. Seed it from
+	// asynq's retry count so run persists the attempt actually being made.
+	job := payload.Job
+	if n, ok := asynq.GetRetryCount(ctx); ok {
+		job.Attempt = n
+	}
+
+	_, err := b.manager.run(job, payload.Settings)
+	return err
+}
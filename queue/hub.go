@@ -0,0 +1,138 @@
+package queue
+
+import "sync"
+
+// hubBacklog is how many trailing lines a hub replays to a subscriber that
+// joins mid-run.
+const hubBacklog = 200
+
+// Frame is a single line of pipeline output, or the terminating "done"
+// frame recording the job's outcome.
+type Frame struct {
+	Event string // "log" or "done"
+	Data  string
+}
+
+// Hub fans a running job's output out to any number of subscribers,
+// replaying a bounded backlog to subscribers that join late.
+type Hub struct {
+	mu      sync.Mutex
+	backlog []Frame
+	subs    map[chan Frame]struct{}
+	closed  bool
+}
+
+func newHub() *Hub {
+	return &Hub{subs: make(map[chan Frame]struct{})}
+}
+
+// Subscribe registers a new subscriber, returning a channel that receives
+// the backlog followed by live frames, and an unsubscribe func. The
+// channel is closed once the hub is finished and all backlog has drained.
+func (h *Hub) Subscribe() (<-chan Frame, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan Frame, hubBacklog+1)
+	for _, frame := range h.backlog {
+		ch <- frame
+	}
+	if h.closed {
+		close(ch)
+		return ch, func() {}
+	}
+
+	h.subs[ch] = struct{}{}
+	unsubscribe := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.subs[ch]; ok {
+			delete(h.subs, ch)
+			close(ch)
+		}
+	}
+	return ch, unsubscribe
+}
+
+// Publish appends a log line to the backlog and forwards it to every
+// current subscriber, dropping it for subscribers whose buffer is full
+// rather than blocking the pipeline.
+func (h *Hub) Publish(line string) {
+	h.publish(Frame{Event: "log", Data: line})
+}
+
+// Done records the job's terminating status and closes out every
+// subscriber's channel.
+func (h *Hub) Done(status string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	frame := Frame{Event: "done", Data: status}
+	h.backlog = append(h.backlog, frame)
+	for ch := range h.subs {
+		select {
+		case ch <- frame:
+		default:
+		}
+		close(ch)
+	}
+	h.subs = make(map[chan Frame]struct{})
+	h.closed = true
+}
+
+func (h *Hub) publish(frame Frame) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.backlog = append(h.backlog, frame)
+	if overflow := len(h.backlog) - hubBacklog; overflow > 0 {
+		h.backlog = h.backlog[overflow:]
+	}
+
+	for ch := range h.subs {
+		select {
+		case ch <- frame:
+		default:
+			// Slow subscriber; drop the line rather than stall the pipeline.
+		}
+	}
+}
+
+// HubRegistry tracks the live Hub for each in-flight job.
+type HubRegistry struct {
+	mu   sync.Mutex
+	hubs map[string]*Hub
+}
+
+// Hubs is the process-wide registry pipeline runs publish to and the SSE
+// endpoint subscribes from.
+var Hubs = &HubRegistry{hubs: make(map[string]*Hub)}
+
+// Register creates (or returns the existing) Hub for a job.
+func (r *HubRegistry) Register(jobID string) *Hub {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if h, ok := r.hubs[jobID]; ok {
+		return h
+	}
+	h := newHub()
+	r.hubs[jobID] = h
+	return h
+}
+
+// Get returns the Hub for a job, if it's currently running.
+func (r *HubRegistry) Get(jobID string) (*Hub, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	h, ok := r.hubs[jobID]
+	return h, ok
+}
+
+// Release drops a finished job's Hub from the registry. Subscribers that
+// already joined keep their channel until it drains.
+func (r *HubRegistry) Release(jobID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.hubs, jobID)
+}
@@ -0,0 +1,85 @@
+package queue
+
+import "testing"
+
+func TestHubReplaysBacklogToLateSubscriber(t *testing.T) {
+	h := newHub()
+	h.Publish("line 1")
+	h.Publish("line 2")
+
+	ch, unsubscribe := h.Subscribe()
+	defer unsubscribe()
+
+	for _, want := range []string{"line 1", "line 2"} {
+		frame := <-ch
+		if frame.Data != want {
+			t.Fatalf("backlog frame = %q, want %q", frame.Data, want)
+		}
+	}
+}
+
+func TestHubBroadcastsToAllSubscribers(t *testing.T) {
+	h := newHub()
+
+	ch1, unsub1 := h.Subscribe()
+	defer unsub1()
+	ch2, unsub2 := h.Subscribe()
+	defer unsub2()
+
+	h.Publish("hello")
+
+	for _, ch := range []<-chan Frame{ch1, ch2} {
+		frame := <-ch
+		if frame.Data != "hello" {
+			t.Fatalf("frame.Data = %q, want %q", frame.Data, "hello")
+		}
+	}
+}
+
+func TestHubDoneClosesSubscriberChannels(t *testing.T) {
+	h := newHub()
+	ch, unsubscribe := h.Subscribe()
+	defer unsubscribe()
+
+	h.Done("succeeded")
+
+	frame, ok := <-ch
+	if !ok || frame.Event != "done" || frame.Data != "succeeded" {
+		t.Fatalf("got frame=%+v ok=%v, want a done frame", frame, ok)
+	}
+	if _, ok := <-ch; ok {
+		t.Fatal("channel should be closed after the done frame")
+	}
+}
+
+func TestHubSubscribeAfterDoneReplaysBacklogThenCloses(t *testing.T) {
+	h := newHub()
+	h.Publish("line 1")
+	h.Done("failed")
+
+	ch, unsubscribe := h.Subscribe()
+	defer unsubscribe()
+
+	frame := <-ch
+	if frame.Data != "line 1" {
+		t.Fatalf("frame.Data = %q, want %q", frame.Data, "line 1")
+	}
+	frame = <-ch
+	if frame.Event != "done" || frame.Data != "failed" {
+		t.Fatalf("frame = %+v, want the done frame", frame)
+	}
+	if _, ok := <-ch; ok {
+		t.Fatal("channel should be closed once the backlog is drained")
+	}
+}
+
+func TestHubBacklogIsBounded(t *testing.T) {
+	h := newHub()
+	for i := 0; i < hubBacklog+50; i++ {
+		h.Publish("line")
+	}
+
+	if len(h.backlog) != hubBacklog {
+		t.Fatalf("len(backlog) = %d, want %d", len(h.backlog), hubBacklog)
+	}
+}
@@ -0,0 +1,57 @@
+// Package queue moves pipeline execution off the upload request and onto a
+// background job queue, so a slow build script no longer holds the
+// uploader's connection open.
+package queue
+
+import (
+	"time"
+
+	"github.com/frixuu/bearpush/storage"
+)
+
+// Status is the lifecycle state of a Job.
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// Job is a single pipeline run for an already-stored artifact.
+type Job struct {
+	ID          string
+	Product     string
+	ArtifactRef storage.ObjectRef
+
+	Status  Status
+	Attempt int
+
+	EnqueuedAt time.Time
+	StartedAt  time.Time
+	FinishedAt time.Time
+
+	// Output holds the tail of the pipeline's combined stdout/stderr, up to
+	// OutputBuffer's cap.
+	Output string
+	// Error is the pipeline's failure reason, if Status is StatusFailed.
+	Error string
+}
+
+// Settings are the per-product knobs controlling how its jobs run, already
+// resolved from their on-disk YAML representation.
+type Settings struct {
+	MaxRetries   int
+	RetryBackoff time.Duration
+	Timeout      time.Duration
+	Concurrency  int
+}
+
+// DefaultSettings are used for products that don't configure their own.
+var DefaultSettings = Settings{
+	MaxRetries:   3,
+	RetryBackoff: 30 * time.Second,
+	Timeout:      10 * time.Minute,
+	Concurrency:  1,
+}
@@ -0,0 +1,143 @@
+package queue
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/frixuu/bearpush/storage"
+	"go.uber.org/zap"
+)
+
+// Runner actually executes a pipeline script for job, writing its combined
+// stdout/stderr to out as it runs. It should honor settings.Timeout.
+type Runner func(job Job, settings Settings, out *OutputBuffer) error
+
+// backend is the thing that actually schedules job execution: either an
+// asynq-backed queue for multi-node installs, or an in-process worker pool
+// for single-node ones.
+type backend interface {
+	enqueue(job Job, settings Settings)
+}
+
+// Manager is the public entry point for enqueuing and inspecting pipeline
+// jobs. It owns the persisted job Store and delegates scheduling to a
+// backend.
+type Manager struct {
+	store   Store
+	runner  Runner
+	backend backend
+	logger  *zap.SugaredLogger
+}
+
+// NewManager builds a Manager. If redisAddr is non-empty, jobs are
+// scheduled via asynq against that Redis instance; otherwise an in-process
+// worker pool is used, suitable for single-node installs.
+func NewManager(store Store, runner Runner, redisAddr string, logger *zap.SugaredLogger) (*Manager, error) {
+	m := &Manager{store: store, runner: runner, logger: logger}
+
+	if redisAddr != "" {
+		b, err := newAsynqBackend(redisAddr, m, logger)
+		if err != nil {
+			return nil, fmt.Errorf("setting up asynq backend: %w", err)
+		}
+		m.backend = b
+	} else {
+		m.backend = newMemoryBackend(m, logger)
+	}
+
+	return m, nil
+}
+
+// Enqueue persists a new pending job for the given artifact and schedules
+// it for execution, returning the new Job.
+func (m *Manager) Enqueue(product string, ref storage.ObjectRef, settings Settings) (Job, error) {
+	id, err := newJobID()
+	if err != nil {
+		return Job{}, fmt.Errorf("generating job id: %w", err)
+	}
+
+	job := Job{
+		ID:          id,
+		Product:     product,
+		ArtifactRef: ref,
+		Status:      StatusPending,
+		EnqueuedAt:  time.Now(),
+	}
+
+	if err := m.store.Save(job); err != nil {
+		return Job{}, fmt.Errorf("persisting job: %w", err)
+	}
+
+	m.backend.enqueue(job, settings)
+	return job, nil
+}
+
+// Get returns the current state of a job.
+func (m *Manager) Get(id string) (Job, bool, error) {
+	return m.store.Get(id)
+}
+
+// Retry re-schedules an existing job against its already-stored artifact,
+// without requiring a re-upload.
+func (m *Manager) Retry(id string, settings Settings) (Job, error) {
+	job, ok, err := m.store.Get(id)
+	if err != nil {
+		return Job{}, err
+	}
+	if !ok {
+		return Job{}, fmt.Errorf("job %s not found", id)
+	}
+
+	job.Status = StatusPending
+	job.Attempt = 0
+	job.Error = ""
+	job.EnqueuedAt = time.Now()
+	if err := m.store.Save(job); err != nil {
+		return Job{}, fmt.Errorf("persisting job: %w", err)
+	}
+
+	m.backend.enqueue(job, settings)
+	return job, nil
+}
+
+// run executes job once, recording its outcome in the Store. It's called
+// by whichever backend is active. It returns the updated Job so callers
+// that retry can thread the incremented Attempt (and other updated
+// fields) into the next call, instead of re-running the stale value they
+// started with.
+func (m *Manager) run(job Job, settings Settings) (Job, error) {
+	job.Attempt++
+	job.Status = StatusRunning
+	job.StartedAt = time.Now()
+	if err := m.store.Save(job); err != nil {
+		m.logger.Warnf("Cannot persist job %s: %s", job.ID, err)
+	}
+
+	out := &OutputBuffer{}
+	runErr := m.runner(job, settings, out)
+
+	job.Output = out.String()
+	job.FinishedAt = time.Now()
+	if runErr != nil {
+		job.Status = StatusFailed
+		job.Error = runErr.Error()
+	} else {
+		job.Status = StatusSucceeded
+		job.Error = ""
+	}
+
+	if err := m.store.Save(job); err != nil {
+		m.logger.Warnf("Cannot persist job %s: %s", job.ID, err)
+	}
+	return job, runErr
+}
+
+func newJobID() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
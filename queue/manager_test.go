@@ -0,0 +1,62 @@
+package queue
+
+import (
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestManagerRunIncrementsAttemptAcrossRetries(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore: %s", err)
+	}
+
+	calls := 0
+	runner := Runner(func(job Job, settings Settings, out *OutputBuffer) error {
+		calls++
+		if calls < 3 {
+			return errors.New("boom")
+		}
+		return nil
+	})
+
+	m := &Manager{store: store, runner: runner, logger: zap.NewNop().Sugar()}
+	job := Job{ID: "abc"}
+
+	job, err = m.run(job, Settings{})
+	if err == nil {
+		t.Fatal("expected first attempt to fail")
+	}
+	if job.Attempt != 1 {
+		t.Fatalf("Attempt after 1st run = %d, want 1", job.Attempt)
+	}
+
+	job, err = m.run(job, Settings{})
+	if err == nil {
+		t.Fatal("expected second attempt to fail")
+	}
+	if job.Attempt != 2 {
+		t.Fatalf("Attempt after 2nd run = %d, want 2", job.Attempt)
+	}
+
+	job, err = m.run(job, Settings{})
+	if err != nil {
+		t.Fatalf("expected third attempt to succeed, got %s", err)
+	}
+	if job.Attempt != 3 {
+		t.Fatalf("Attempt after 3rd run = %d, want 3", job.Attempt)
+	}
+	if job.Status != StatusSucceeded {
+		t.Fatalf("Status = %s, want %s", job.Status, StatusSucceeded)
+	}
+
+	persisted, ok, err := store.Get("abc")
+	if err != nil || !ok {
+		t.Fatalf("Get: ok=%v err=%s", ok, err)
+	}
+	if persisted.Attempt != 3 {
+		t.Fatalf("persisted Attempt = %d, want 3", persisted.Attempt)
+	}
+}
@@ -0,0 +1,72 @@
+package queue
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// memoryBackend runs jobs using a small worker pool per product, entirely
+// in-process. It's the fallback used when no Redis instance is configured,
+// and loses any queued-but-not-started jobs across a restart.
+type memoryBackend struct {
+	manager *Manager
+	logger  *zap.SugaredLogger
+
+	mu    sync.Mutex
+	pools map[string]chan Job
+}
+
+func newMemoryBackend(manager *Manager, logger *zap.SugaredLogger) *memoryBackend {
+	return &memoryBackend{
+		manager: manager,
+		logger:  logger,
+		pools:   make(map[string]chan Job),
+	}
+}
+
+func (b *memoryBackend) enqueue(job Job, settings Settings) {
+	queueCh := b.poolFor(job.Product, settings)
+	queueCh <- job
+}
+
+// poolFor returns the worker channel for a product, lazily starting its
+// pool of settings.Concurrency workers the first time it's needed.
+func (b *memoryBackend) poolFor(product string, settings Settings) chan Job {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if ch, ok := b.pools[product]; ok {
+		return ch
+	}
+
+	concurrency := settings.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	ch := make(chan Job, 64)
+	for i := 0; i < concurrency; i++ {
+		go b.worker(ch, settings)
+	}
+	b.pools[product] = ch
+	return ch
+}
+
+func (b *memoryBackend) worker(queueCh chan Job, settings Settings) {
+	for job := range queueCh {
+		maxRetries := settings.MaxRetries
+		backoff := settings.RetryBackoff
+		if backoff <= 0 {
+			backoff = DefaultSettings.RetryBackoff
+		}
+
+		job, err := b.manager.run(job, settings)
+		for attempt := 1; err != nil && attempt <= maxRetries; attempt++ {
+			b.logger.Warnf("Job %s failed (attempt %d/%d): %s", job.ID, attempt, maxRetries+1, err)
+			time.Sleep(backoff * time.Duration(attempt))
+			job, err = b.manager.run(job, settings)
+		}
+	}
+}
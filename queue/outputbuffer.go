@@ -0,0 +1,34 @@
+package queue
+
+import "sync"
+
+// outputBufferLimit is how many trailing bytes of combined stdout/stderr a
+// job keeps around for its status API.
+const outputBufferLimit = 64 * 1024
+
+// OutputBuffer is a bounded, concurrency-safe sink for a running pipeline's
+// combined stdout/stderr, keeping only the last outputBufferLimit bytes.
+type OutputBuffer struct {
+	mu   sync.Mutex
+	data []byte
+}
+
+// Write implements io.Writer, trimming from the front once the buffer grows
+// past its limit.
+func (b *OutputBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.data = append(b.data, p...)
+	if overflow := len(b.data) - outputBufferLimit; overflow > 0 {
+		b.data = b.data[overflow:]
+	}
+	return len(p), nil
+}
+
+// String returns the currently buffered output.
+func (b *OutputBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.data)
+}
@@ -0,0 +1,72 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Store persists Job records so a bearpush restart doesn't lose job
+// history or in-flight status.
+type Store interface {
+	Save(job Job) error
+	Get(id string) (Job, bool, error)
+}
+
+// FileStore keeps one JSON file per job under Dir.
+type FileStore struct {
+	Dir string
+
+	mu sync.Mutex
+}
+
+// NewFileStore creates a FileStore rooted at dir, creating it if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil && !os.IsExist(err) {
+		return nil, err
+	}
+	return &FileStore{Dir: dir}, nil
+}
+
+func (s *FileStore) path(id string) string {
+	return filepath.Join(s.Dir, id+".json")
+}
+
+// Save implements Store.
+func (s *FileStore) Save(job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	tmp := s.path(job.ID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0640); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path(job.ID))
+}
+
+// Get implements Store.
+func (s *FileStore) Get(id string) (Job, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.path(id))
+	if os.IsNotExist(err) {
+		return Job{}, false, nil
+	}
+	if err != nil {
+		return Job{}, false, fmt.Errorf("reading job record: %w", err)
+	}
+
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return Job{}, false, fmt.Errorf("parsing job record: %w", err)
+	}
+	return job, true, nil
+}
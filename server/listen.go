@@ -0,0 +1,85 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/frixuu/bearpush"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// ListenMode and ListenConfig live in the bearpush package (alongside the
+// rest of the on-disk config) so both this package and the CLI can depend
+// on them without an import cycle. Aliased here so callers can keep saying
+// server.ListenConfig.
+type (
+	ListenMode   = bearpush.ListenMode
+	ListenConfig = bearpush.ListenConfig
+)
+
+const (
+	ListenModeTCP     = bearpush.ListenModeTCP
+	ListenModeTLS     = bearpush.ListenModeTLS
+	ListenModeSystemd = bearpush.ListenModeSystemd
+)
+
+// listenersFromSystemd builds net.Listeners from the file descriptors
+// systemd passes on socket activation, starting at fd 3. It returns
+// (nil, nil) if this process was not socket-activated.
+func listenersFromSystemd() ([]net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return nil, nil
+	}
+
+	listeners := make([]net.Listener, 0, count)
+	for i := 0; i < count; i++ {
+		file := os.NewFile(uintptr(3+i), fmt.Sprintf("listener%d", i))
+		l, err := net.FileListener(file)
+		if err != nil {
+			return nil, fmt.Errorf("building listener from fd %d: %w", 3+i, err)
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
+
+// Listeners resolves cfg into the net.Listeners srv should Serve on, and
+// an *autocert.Manager when TLS certificates should be minted on demand
+// (nil otherwise). TLS itself, if any, is left for the caller to apply via
+// tls.NewListener or http.Server.ServeTLS.
+func Listeners(cfg ListenConfig, config *bearpush.Config) ([]net.Listener, *autocert.Manager, error) {
+	if cfg.Mode == ListenModeSystemd {
+		if listeners, err := listenersFromSystemd(); err != nil {
+			return nil, nil, err
+		} else if len(listeners) > 0 {
+			return listeners, nil, nil
+		}
+		// Not actually socket-activated (e.g. running interactively);
+		// fall through to binding cfg.Addr ourselves.
+	}
+
+	l, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("binding %s: %w", cfg.Addr, err)
+	}
+
+	if cfg.Mode == ListenModeTLS && len(cfg.AutocertHosts) > 0 {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.AutocertHosts...),
+			Cache:      autocert.DirCache(filepath.Join(config.Path, "acme")),
+		}
+		return []net.Listener{l}, manager, nil
+	}
+
+	return []net.Listener{l}, nil, nil
+}
@@ -0,0 +1,123 @@
+// Package server hosts the HTTP transport concerns of bearpush: picking a
+// listen address, starting/stopping the HTTP server, and the auth
+// middleware its routes are guarded by.
+package server
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/frixuu/bearpush"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// DeterminePort returns the address bearpush should bind to, honoring the
+// PORT environment variable used by most PaaS providers and falling back to
+// :8080 otherwise.
+func DeterminePort() string {
+	if port := os.Getenv("PORT"); port != "" {
+		return ":" + port
+	}
+	return ":8080"
+}
+
+// Start binds srv according to cfg (plain TCP, TLS, or systemd socket
+// activation) and serves on it until it is shut down, logging the outcome.
+// It is meant to be called in a goroutine from main.
+func Start(srv *http.Server, cfg ListenConfig, config *bearpush.Config, logger *zap.Logger) {
+	listeners, acmeManager, err := Listeners(cfg, config)
+	if err != nil {
+		logger.Fatal("Could not set up listener", zap.Error(err))
+	}
+
+	if acmeManager != nil {
+		srv.TLSConfig = acmeManager.TLSConfig()
+	}
+
+	var wg sync.WaitGroup
+	for _, l := range listeners {
+		wg.Add(1)
+		go func(l net.Listener) {
+			defer wg.Done()
+			serve(srv, cfg, l, logger)
+		}(l)
+	}
+	wg.Wait()
+}
+
+func serve(srv *http.Server, cfg ListenConfig, l net.Listener, logger *zap.Logger) {
+	var err error
+	if cfg.Mode == ListenModeTLS {
+		certFile, keyFile := cfg.Cert, cfg.Key
+		if srv.TLSConfig != nil {
+			// Certificates are minted on demand by autocert.
+			certFile, keyFile = "", ""
+		}
+		err = srv.ServeTLS(l, certFile, keyFile)
+	} else {
+		err = srv.Serve(l)
+	}
+
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Fatal("Could not start server", zap.Error(err))
+	}
+}
+
+// ValidateToken returns a middleware that rejects requests to a product's
+// endpoints that don't present the token required by that product's
+// TokenSettings.
+func ValidateToken(appContext *bearpush.AppContext) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		product := c.Param("product")
+		p, ok := appContext.Products[product]
+		if !ok {
+			// Let the handler itself report the missing product so the
+			// error message stays consistent across routes.
+			c.Next()
+			return
+		}
+
+		if err := CheckToken(p, c.GetHeader("X-Bearpush-Token")); err != nil {
+			status := http.StatusUnauthorized
+			if errors.Is(err, ErrUnknownTokenStrategy) {
+				status = http.StatusInternalServerError
+			}
+			c.AbortWithStatusJSON(status, gin.H{
+				"error":   1,
+				"message": err.Error(),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// ErrUnknownTokenStrategy is returned by CheckToken when a product names a
+// TokenStrategy that isn't recognized.
+var ErrUnknownTokenStrategy = errors.New("product has an unknown token strategy configured")
+
+// ErrInvalidToken is returned by CheckToken when the presented token
+// doesn't match what the product requires.
+var ErrInvalidToken = errors.New("invalid or missing token")
+
+// CheckToken validates token against a product's TokenSettings, for
+// handlers that can't use the ValidateToken middleware directly because
+// the product name isn't a plain :product route param (e.g. the Atom feed,
+// whose URL also carries a .atom suffix).
+func CheckToken(p bearpush.Product, token string) error {
+	switch p.TokenSettings.Strategy {
+	case bearpush.TokenStrategyNone, "":
+		return nil
+	case bearpush.TokenStrategyStatic:
+		if token == "" || token != p.TokenSettings.Token {
+			return ErrInvalidToken
+		}
+		return nil
+	default:
+		return ErrUnknownTokenStrategy
+	}
+}
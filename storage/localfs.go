@@ -0,0 +1,284 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// sidecarExt is the suffix of the JSON file storing an artifact's
+// ObjectRef metadata (version, hash, ...) next to it, since that isn't
+// recoverable from the artifact's key alone.
+const sidecarExt = ".json"
+
+// LocalFS stores artifacts on the local filesystem, under
+// <Root>/<product>/<timestamp>-<hash>.
+type LocalFS struct {
+	Root      string
+	Retention Retention
+}
+
+// NewLocalFS creates a LocalFS rooted at root, creating the directory if it
+// does not already exist.
+func NewLocalFS(root string, retention Retention) (*LocalFS, error) {
+	if err := os.MkdirAll(root, 0750); err != nil && !os.IsExist(err) {
+		return nil, err
+	}
+	return &LocalFS{Root: root, Retention: retention}, nil
+}
+
+func (l *LocalFS) productDir(product string) string {
+	return filepath.Join(l.Root, product)
+}
+
+// Put implements Storage.
+func (l *LocalFS) Put(product, version string, r io.Reader, size int64) (ObjectRef, error) {
+	dir := l.productDir(product)
+	if err := os.MkdirAll(dir, 0750); err != nil && !os.IsExist(err) {
+		return ObjectRef{}, err
+	}
+
+	uploadedAt := time.Now()
+	tmp, err := os.CreateTemp(dir, ".upload-*")
+	if err != nil {
+		return ObjectRef{}, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(tmp, io.TeeReader(r, hasher)); err != nil {
+		return ObjectRef{}, fmt.Errorf("writing artifact: %w", err)
+	}
+
+	sum := hex.EncodeToString(hasher.Sum(nil))
+	key := fmt.Sprintf("%d-%s", uploadedAt.Unix(), sum[:12])
+	finalPath := filepath.Join(dir, key)
+
+	if err := tmp.Close(); err != nil {
+		return ObjectRef{}, err
+	}
+	if err := os.Rename(tmp.Name(), finalPath); err != nil {
+		return ObjectRef{}, fmt.Errorf("finalizing artifact: %w", err)
+	}
+
+	ref := ObjectRef{
+		Product:    product,
+		Version:    version,
+		Key:        key,
+		SHA256:     sum,
+		Size:       size,
+		UploadedAt: uploadedAt,
+	}
+
+	if err := l.writeSidecar(dir, key, ref); err != nil {
+		return ObjectRef{}, fmt.Errorf("writing artifact metadata: %w", err)
+	}
+
+	if err := l.enforceRetention(product); err != nil {
+		zap.S().Warnf("Could not enforce retention policy for %s: %s", product, err)
+	}
+
+	return ref, nil
+}
+
+// Adopt implements Adopter: it takes ownership of the already-complete
+// file at path by hashing it in place and renaming it into the product's
+// directory, rather than streaming its bytes through Put and paying for
+// a second full copy of (potentially) a multi-gigabyte artifact.
+func (l *LocalFS) Adopt(product, version, path string, size int64) (ObjectRef, error) {
+	dir := l.productDir(product)
+	if err := os.MkdirAll(dir, 0750); err != nil && !os.IsExist(err) {
+		return ObjectRef{}, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return ObjectRef{}, fmt.Errorf("opening finished upload: %w", err)
+	}
+	hasher := sha256.New()
+	_, err = io.Copy(hasher, f)
+	f.Close()
+	if err != nil {
+		return ObjectRef{}, fmt.Errorf("hashing finished upload: %w", err)
+	}
+
+	uploadedAt := time.Now()
+	sum := hex.EncodeToString(hasher.Sum(nil))
+	key := fmt.Sprintf("%d-%s", uploadedAt.Unix(), sum[:12])
+	finalPath := filepath.Join(dir, key)
+
+	if err := os.Rename(path, finalPath); err != nil {
+		if !errors.Is(err, syscall.EXDEV) {
+			return ObjectRef{}, fmt.Errorf("adopting artifact: %w", err)
+		}
+		// path lives on a different filesystem (e.g. the tus upload
+		// directory and the artifact root are separate mounts); rename
+		// can't do a cheap move across devices, so fall back to a copy.
+		if err := copyFile(path, finalPath); err != nil {
+			return ObjectRef{}, fmt.Errorf("adopting artifact: %w", err)
+		}
+		_ = os.Remove(path)
+	}
+
+	ref := ObjectRef{
+		Product:    product,
+		Version:    version,
+		Key:        key,
+		SHA256:     sum,
+		Size:       size,
+		UploadedAt: uploadedAt,
+	}
+
+	if err := l.writeSidecar(dir, key, ref); err != nil {
+		return ObjectRef{}, fmt.Errorf("writing artifact metadata: %w", err)
+	}
+
+	if err := l.enforceRetention(product); err != nil {
+		zap.S().Warnf("Could not enforce retention policy for %s: %s", product, err)
+	}
+
+	return ref, nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// Get implements Storage.
+func (l *LocalFS) Get(ref ObjectRef) (io.ReadCloser, error) {
+	f, err := os.Open(filepath.Join(l.productDir(ref.Product), ref.Key))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// List implements Storage.
+func (l *LocalFS) List(product, prefix string, limit int) ([]ObjectRef, error) {
+	dir := l.productDir(product)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]ObjectRef, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasSuffix(entry.Name(), sidecarExt) {
+			continue
+		}
+
+		ref, err := l.readSidecar(dir, entry.Name())
+		if err != nil {
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			ref = ObjectRef{Product: product, Key: entry.Name(), Size: info.Size(), UploadedAt: info.ModTime()}
+		}
+
+		if prefix != "" && !strings.HasPrefix(ref.Version, prefix) {
+			continue
+		}
+		refs = append(refs, ref)
+	}
+
+	sort.Slice(refs, func(i, j int) bool { return refs[i].UploadedAt.After(refs[j].UploadedAt) })
+	if limit > 0 && len(refs) > limit {
+		refs = refs[:limit]
+	}
+	return refs, nil
+}
+
+// writeSidecar persists ref's metadata as a small JSON file next to the
+// artifact itself, keyed by its storage key, so List can recover Version
+// and SHA256 without guessing from the filename.
+func (l *LocalFS) writeSidecar(dir, key string, ref ObjectRef) error {
+	data, err := json.Marshal(ref)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, key+sidecarExt), data, 0640)
+}
+
+// readSidecar loads the ObjectRef metadata written by writeSidecar for key.
+func (l *LocalFS) readSidecar(dir, key string) (ObjectRef, error) {
+	data, err := os.ReadFile(filepath.Join(dir, key+sidecarExt))
+	if err != nil {
+		return ObjectRef{}, err
+	}
+	var ref ObjectRef
+	if err := json.Unmarshal(data, &ref); err != nil {
+		return ObjectRef{}, err
+	}
+	return ref, nil
+}
+
+// LocateForScript implements ScriptLocator.
+func (l *LocalFS) LocateForScript(ref ObjectRef) (string, error) {
+	return filepath.Join(l.productDir(ref.Product), ref.Key), nil
+}
+
+// Delete implements Storage.
+func (l *LocalFS) Delete(ref ObjectRef) error {
+	dir := l.productDir(ref.Product)
+	err := os.Remove(filepath.Join(dir, ref.Key))
+	if os.IsNotExist(err) {
+		return ErrNotFound
+	}
+	_ = os.Remove(filepath.Join(dir, ref.Key+sidecarExt))
+	return err
+}
+
+// enforceRetention deletes artifacts that no longer satisfy l.Retention.
+func (l *LocalFS) enforceRetention(product string) error {
+	if l.Retention.KeepLast <= 0 && l.Retention.MaxAge <= 0 {
+		return nil
+	}
+
+	refs, err := l.List(product, "", 0)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i, ref := range refs {
+		expired := l.Retention.MaxAge > 0 && now.Sub(ref.UploadedAt) > l.Retention.MaxAge
+		overflow := l.Retention.KeepLast > 0 && i >= l.Retention.KeepLast
+		if expired || overflow {
+			if err := l.Delete(ref); err != nil {
+				zap.S().Warnf("Could not prune %s/%s: %s", product, ref.Key, err)
+			}
+		}
+	}
+	return nil
+}
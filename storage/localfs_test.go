@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocalFSPutListRoundTripsVersionAndHash(t *testing.T) {
+	l, err := NewLocalFS(t.TempDir(), Retention{})
+	if err != nil {
+		t.Fatalf("NewLocalFS: %s", err)
+	}
+
+	ref, err := l.Put("demo", "1.2.3", strings.NewReader("hello"), 5)
+	if err != nil {
+		t.Fatalf("Put: %s", err)
+	}
+	if ref.Version != "1.2.3" {
+		t.Fatalf("ref.Version = %q, want %q", ref.Version, "1.2.3")
+	}
+	if ref.SHA256 == "" {
+		t.Fatal("ref.SHA256 is empty")
+	}
+
+	refs, err := l.List("demo", "", 0)
+	if err != nil {
+		t.Fatalf("List: %s", err)
+	}
+	if len(refs) != 1 {
+		t.Fatalf("List returned %d refs, want 1", len(refs))
+	}
+	if refs[0].Version != ref.Version || refs[0].SHA256 != ref.SHA256 {
+		t.Fatalf("List returned %+v, want version/hash matching Put's %+v", refs[0], ref)
+	}
+}
+
+func TestLocalFSListFiltersByVersionPrefix(t *testing.T) {
+	l, err := NewLocalFS(t.TempDir(), Retention{})
+	if err != nil {
+		t.Fatalf("NewLocalFS: %s", err)
+	}
+
+	if _, err := l.Put("demo", "1.0.0", strings.NewReader("a"), 1); err != nil {
+		t.Fatalf("Put: %s", err)
+	}
+	if _, err := l.Put("demo", "2.0.0", strings.NewReader("b"), 1); err != nil {
+		t.Fatalf("Put: %s", err)
+	}
+
+	refs, err := l.List("demo", "1.", 0)
+	if err != nil {
+		t.Fatalf("List: %s", err)
+	}
+	if len(refs) != 1 || refs[0].Version != "1.0.0" {
+		t.Fatalf("List(%q) = %+v, want exactly the 1.0.0 artifact", "1.", refs)
+	}
+}
+
+func TestLocalFSEnforceRetentionKeepsMostRecent(t *testing.T) {
+	l, err := NewLocalFS(t.TempDir(), Retention{KeepLast: 1})
+	if err != nil {
+		t.Fatalf("NewLocalFS: %s", err)
+	}
+
+	if _, err := l.Put("demo", "1.0.0", strings.NewReader("a"), 1); err != nil {
+		t.Fatalf("Put: %s", err)
+	}
+	time.Sleep(time.Millisecond) // ensure distinct UploadedAt ordering
+	if _, err := l.Put("demo", "2.0.0", strings.NewReader("b"), 1); err != nil {
+		t.Fatalf("Put: %s", err)
+	}
+
+	refs, err := l.List("demo", "", 0)
+	if err != nil {
+		t.Fatalf("List: %s", err)
+	}
+	if len(refs) != 1 || refs[0].Version != "2.0.0" {
+		t.Fatalf("after retention, List = %+v, want only 2.0.0", refs)
+	}
+}
+
+func TestLocalFSAdoptMovesFileAndRecordsHash(t *testing.T) {
+	l, err := NewLocalFS(t.TempDir(), Retention{})
+	if err != nil {
+		t.Fatalf("NewLocalFS: %s", err)
+	}
+
+	srcDir := t.TempDir()
+	srcPath := filepath.Join(srcDir, "upload.part")
+	if err := os.WriteFile(srcPath, []byte("hello"), 0640); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+
+	ref, err := l.Adopt("demo", "1.2.3", srcPath, 5)
+	if err != nil {
+		t.Fatalf("Adopt: %s", err)
+	}
+	if ref.Version != "1.2.3" || ref.SHA256 == "" {
+		t.Fatalf("ref = %+v, want populated Version/SHA256", ref)
+	}
+
+	if _, err := os.Stat(srcPath); !os.IsNotExist(err) {
+		t.Fatalf("source file still exists at %s after Adopt", srcPath)
+	}
+
+	rc, err := l.Get(ref)
+	if err != nil {
+		t.Fatalf("Get: %s", err)
+	}
+	defer rc.Close()
+}
+
+func TestLocalFSListPrefixMatchIncludesNewerLongerVersion(t *testing.T) {
+	// List filters by prefix, not equality: a "1.0" lookup also matches a
+	// newer "1.0.5". Callers that need an exact version (e.g. the
+	// download endpoint) must filter the results themselves; this test
+	// documents that List alone is not enough for that.
+	l, err := NewLocalFS(t.TempDir(), Retention{})
+	if err != nil {
+		t.Fatalf("NewLocalFS: %s", err)
+	}
+
+	if _, err := l.Put("demo", "1.0", strings.NewReader("a"), 1); err != nil {
+		t.Fatalf("Put: %s", err)
+	}
+	time.Sleep(time.Millisecond)
+	if _, err := l.Put("demo", "1.0.5", strings.NewReader("b"), 1); err != nil {
+		t.Fatalf("Put: %s", err)
+	}
+
+	refs, err := l.List("demo", "1.0", 0)
+	if err != nil {
+		t.Fatalf("List: %s", err)
+	}
+	if len(refs) != 2 {
+		t.Fatalf("List(%q) = %+v, want both 1.0 and 1.0.5 to match the prefix", "1.0", refs)
+	}
+	if refs[0].Version != "1.0.5" {
+		t.Fatalf("refs[0].Version = %q, want the newer 1.0.5 first", refs[0].Version)
+	}
+}
@@ -0,0 +1,217 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Config configures the S3 backend, either against AWS S3 proper or an
+// S3-compatible service such as MinIO.
+type S3Config struct {
+	Endpoint  string `yaml:"endpoint"`
+	Bucket    string `yaml:"bucket"`
+	AccessKey string `yaml:"access_key"`
+	SecretKey string `yaml:"secret_key"`
+	UseSSL    bool   `yaml:"use_ssl"`
+	Retention Retention
+}
+
+// S3 stores artifacts in an S3-compatible object store, keyed as
+// <product>/<timestamp>-<hash>.
+type S3 struct {
+	client    *minio.Client
+	bucket    string
+	retention Retention
+}
+
+// NewS3 connects to the object store described by cfg and ensures the
+// configured bucket exists.
+func NewS3(cfg S3Config) (*S3, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("connecting to object store: %w", err)
+	}
+
+	ctx := context.Background()
+	exists, err := client.BucketExists(ctx, cfg.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("checking bucket: %w", err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, cfg.Bucket, minio.MakeBucketOptions{}); err != nil {
+			return nil, fmt.Errorf("creating bucket: %w", err)
+		}
+	}
+
+	return &S3{client: client, bucket: cfg.Bucket, retention: cfg.Retention}, nil
+}
+
+func objectKey(product, key string) string {
+	return product + "/" + key
+}
+
+// userMeta looks up a user metadata value by name, tolerant of the
+// "X-Amz-Meta-" prefixing/casing servers vary on when echoing it back.
+func userMeta(meta map[string]string, name string) string {
+	for _, key := range []string{name, "X-Amz-Meta-" + name, "x-amz-meta-" + name} {
+		if v, ok := meta[key]; ok {
+			return v
+		}
+	}
+	return ""
+}
+
+// Put implements Storage.
+func (s *S3) Put(product, version string, r io.Reader, size int64) (ObjectRef, error) {
+	// minio-go needs to know the size up front for single-shot PUTs, but we
+	// also want the sha256 of the stream, so buffer the hash via a pipe.
+	hasher := sha256.New()
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := io.Copy(pw, io.TeeReader(r, hasher))
+		pw.CloseWithError(err)
+	}()
+
+	uploadedAt := time.Now()
+	// Key is finalized once we know the hash, but the PUT must happen
+	// before that's available, so upload to a staging key first.
+	stagingKey := objectKey(product, fmt.Sprintf("%d-staging", uploadedAt.UnixNano()))
+
+	ctx := context.Background()
+	if _, err := s.client.PutObject(ctx, s.bucket, stagingKey, pr, size, minio.PutObjectOptions{
+		ContentType: "application/octet-stream",
+	}); err != nil {
+		return ObjectRef{}, fmt.Errorf("uploading artifact: %w", err)
+	}
+
+	sum := hex.EncodeToString(hasher.Sum(nil))
+	key := fmt.Sprintf("%d-%s", uploadedAt.Unix(), sum[:12])
+	finalKey := objectKey(product, key)
+
+	// Version and SHA256 aren't recoverable from the object key alone, so
+	// carry them as user metadata, set via the copy that finalizes the key.
+	if _, err := s.client.CopyObject(ctx,
+		minio.CopyDestOptions{
+			Bucket: s.bucket,
+			Object: finalKey,
+			UserMetadata: map[string]string{
+				"version": version,
+				"sha256":  sum,
+			},
+			ReplaceMetadata: true,
+		},
+		minio.CopySrcOptions{Bucket: s.bucket, Object: stagingKey},
+	); err != nil {
+		return ObjectRef{}, fmt.Errorf("finalizing artifact: %w", err)
+	}
+	_ = s.client.RemoveObject(ctx, s.bucket, stagingKey, minio.RemoveObjectOptions{})
+
+	ref := ObjectRef{
+		Product:    product,
+		Version:    version,
+		Key:        key,
+		SHA256:     sum,
+		Size:       size,
+		UploadedAt: uploadedAt,
+	}
+
+	s.enforceRetention(product)
+	return ref, nil
+}
+
+// Get implements Storage.
+func (s *S3) Get(ref ObjectRef) (io.ReadCloser, error) {
+	obj, err := s.client.GetObject(context.Background(), s.bucket, objectKey(ref.Product, ref.Key), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := obj.Stat(); err != nil {
+		errResp := minio.ToErrorResponse(err)
+		if errResp.Code == "NoSuchKey" {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return obj, nil
+}
+
+// List implements Storage.
+func (s *S3) List(product, prefix string, limit int) ([]ObjectRef, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var refs []ObjectRef
+	for obj := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{
+		Prefix:       objectKey(product, ""),
+		WithMetadata: true,
+	}) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		version := userMeta(obj.UserMetadata, "version")
+		if prefix != "" && !strings.HasPrefix(version, prefix) {
+			continue
+		}
+		refs = append(refs, ObjectRef{
+			Product:    product,
+			Version:    version,
+			Key:        obj.Key[len(product)+1:],
+			SHA256:     userMeta(obj.UserMetadata, "sha256"),
+			Size:       obj.Size,
+			UploadedAt: obj.LastModified,
+		})
+	}
+
+	sort.Slice(refs, func(i, j int) bool { return refs[i].UploadedAt.After(refs[j].UploadedAt) })
+	if limit > 0 && len(refs) > limit {
+		refs = refs[:limit]
+	}
+	return refs, nil
+}
+
+// LocateForScript implements ScriptLocator, handing back a short-lived
+// presigned URL the pipeline script can download the artifact from.
+func (s *S3) LocateForScript(ref ObjectRef) (string, error) {
+	url, err := s.client.PresignedGetObject(context.Background(), s.bucket, objectKey(ref.Product, ref.Key), time.Hour, nil)
+	if err != nil {
+		return "", err
+	}
+	return url.String(), nil
+}
+
+// Delete implements Storage.
+func (s *S3) Delete(ref ObjectRef) error {
+	return s.client.RemoveObject(context.Background(), s.bucket, objectKey(ref.Product, ref.Key), minio.RemoveObjectOptions{})
+}
+
+func (s *S3) enforceRetention(product string) {
+	if s.retention.KeepLast <= 0 && s.retention.MaxAge <= 0 {
+		return
+	}
+
+	refs, err := s.List(product, "", 0)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	for i, ref := range refs {
+		expired := s.retention.MaxAge > 0 && now.Sub(ref.UploadedAt) > s.retention.MaxAge
+		overflow := s.retention.KeepLast > 0 && i >= s.retention.KeepLast
+		if expired || overflow {
+			_ = s.Delete(ref)
+		}
+	}
+}
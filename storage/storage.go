@@ -0,0 +1,70 @@
+// Package storage persists uploaded artifacts so they can be served back to
+// consumers after the pipeline script that produced them has exited.
+package storage
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNotFound is returned by Get when the requested object does not exist.
+var ErrNotFound = errors.New("storage: object not found")
+
+// ObjectRef identifies a stored artifact and carries the metadata needed to
+// serve it back without re-reading the backend.
+type ObjectRef struct {
+	Product   string
+	Version   string
+	Key       string
+	SHA256    string
+	Size      int64
+	UploadedAt time.Time
+}
+
+// Retention describes how long stored artifacts for a product should be
+// kept around.
+type Retention struct {
+	// KeepLast, if > 0, keeps only the most recent N artifacts for a
+	// product, deleting older ones.
+	KeepLast int `yaml:"keep_last"`
+	// MaxAge, if > 0, deletes artifacts older than this.
+	MaxAge time.Duration `yaml:"max_age"`
+}
+
+// Storage is a backend capable of persisting and retrieving artifacts.
+// Implementations must be safe for concurrent use.
+type Storage interface {
+	// Put streams size bytes from r into the backend under product/version,
+	// returning a reference to the stored object. Implementations apply
+	// their configured Retention policy after a successful write.
+	Put(product, version string, r io.Reader, size int64) (ObjectRef, error)
+
+	// Get opens the object referenced by ref for reading. Callers must
+	// close the returned ReadCloser.
+	Get(ref ObjectRef) (io.ReadCloser, error)
+
+	// List returns up to limit object refs for product, newest first,
+	// optionally filtered to versions sharing prefix. A limit <= 0 means no
+	// limit.
+	List(product, prefix string, limit int) ([]ObjectRef, error)
+
+	// Delete removes the object referenced by ref.
+	Delete(ref ObjectRef) error
+}
+
+// ScriptLocator is implemented by backends that can hand a pipeline script
+// a direct path or URL to an artifact, instead of requiring it to call
+// back into bearpush to read it.
+type ScriptLocator interface {
+	LocateForScript(ref ObjectRef) (string, error)
+}
+
+// Adopter is implemented by backends that can take ownership of a file
+// that's already complete on local disk (such as the part file a
+// finished tus.io upload leaves behind) by moving it into place, instead
+// of requiring callers to stream it through Put and pay for a second
+// full copy (and, for a remote backend, a second upload).
+type Adopter interface {
+	Adopt(product, version, path string, size int64) (ObjectRef, error)
+}
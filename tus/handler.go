@@ -0,0 +1,162 @@
+package tus
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CompletionFunc is invoked once an upload's final chunk has landed on
+// disk. Implementations are expected to move partPath into permanent
+// storage (and typically kick off whatever happens after a normal
+// multipart upload, e.g. enqueuing a pipeline job).
+type CompletionFunc func(c *gin.Context, product string, upload *Upload, partPath string) error
+
+// MaxSizeFunc returns the maximum artifact size allowed for a product, or
+// 0 for no limit.
+type MaxSizeFunc func(product string) int64
+
+// ExistsFunc reports whether product is a known, configured product.
+//
+// server.ValidateToken intentionally no-ops for an unknown product,
+// deferring the 400 to the handler (as the classic multipart upload
+// handler does). CreateHandler must make the same check itself, and
+// before store.Create, so a made-up product name never gets as far as an
+// upload session a client could PATCH arbitrary bytes into.
+type ExistsFunc func(product string) bool
+
+// writeResumableHeader sets the header every tus response must carry.
+func writeResumableHeader(c *gin.Context) {
+	c.Header("Tus-Resumable", ResumableVersion)
+}
+
+// OptionsHandler answers the tus capability discovery request.
+func OptionsHandler(c *gin.Context) {
+	writeResumableHeader(c)
+	c.Header("Tus-Version", ResumableVersion)
+	c.Header("Tus-Extension", Extensions)
+	c.Status(http.StatusNoContent)
+}
+
+// CreateHandler implements the tus Creation extension: POST with
+// Upload-Length and optional Upload-Metadata, responding with a Location
+// the client PATCHes subsequent chunks to.
+func CreateHandler(store *Store, ttl time.Duration, exists ExistsFunc, maxSize MaxSizeFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		product := c.Param("product")
+		if !exists(product) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   4,
+				"message": "Resource does not exist.",
+			})
+			return
+		}
+
+		length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+		if err != nil || length < 0 {
+			c.String(http.StatusBadRequest, "Missing or invalid Upload-Length header.")
+			return
+		}
+		if limit := maxSize(product); limit > 0 && length > limit {
+			c.Status(http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		metadata := ParseMetadata(c.GetHeader("Upload-Metadata"))
+		upload, err := store.Create(length, metadata, ttl)
+		if err != nil {
+			c.String(http.StatusInternalServerError, "Could not start upload.")
+			return
+		}
+
+		writeResumableHeader(c)
+		c.Header("Upload-Expires", upload.Expires.UTC().Format(http.TimeFormat))
+		c.Header("Location", c.Request.URL.Path+"/"+upload.ID)
+		c.Status(http.StatusCreated)
+	}
+}
+
+// HeadHandler reports an upload's current Upload-Offset, so a client can
+// resume after a dropped connection.
+func HeadHandler(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		upload, err := store.Get(c.Param("id"))
+		if err != nil {
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		writeResumableHeader(c)
+		c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+		c.Header("Upload-Length", strconv.FormatInt(upload.Length, 10))
+		c.Header("Upload-Expires", upload.Expires.UTC().Format(http.TimeFormat))
+		c.Header("Cache-Control", "no-store")
+		c.Status(http.StatusOK)
+	}
+}
+
+// PatchHandler appends a chunk at the client-supplied Upload-Offset. Once
+// the upload reaches its declared Upload-Length, onComplete is called with
+// the finished part file and the upload is then dropped from the store.
+func PatchHandler(store *Store, onComplete CompletionFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Content-Type") != "application/offset+octet-stream" {
+			c.Status(http.StatusUnsupportedMediaType)
+			return
+		}
+
+		offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+		if err != nil {
+			c.String(http.StatusBadRequest, "Missing or invalid Upload-Offset header.")
+			return
+		}
+
+		id := c.Param("id")
+		upload, err := store.Get(id)
+		if err != nil {
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		upload, err = store.WriteChunk(upload, offset, c.Request.Body)
+		if err == ErrOffsetMismatch {
+			c.Status(http.StatusConflict)
+			return
+		} else if err != nil {
+			c.String(http.StatusInternalServerError, "Could not write upload chunk.")
+			return
+		}
+
+		writeResumableHeader(c)
+		c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+
+		if upload.Offset >= upload.Length {
+			if err := onComplete(c, c.Param("product"), upload, store.PartPath(upload.ID)); err != nil {
+				c.String(http.StatusUnprocessableEntity, "Upload finished but could not be processed: %s", err)
+				return
+			}
+			if err := store.Delete(upload.ID); err != nil {
+				// The artifact is already safely handed off to onComplete;
+				// a leftover temp file isn't worth failing the request over.
+				_ = err
+			}
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// DeleteHandler implements the tus Termination extension, abandoning an
+// in-progress upload.
+func DeleteHandler(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := store.Delete(c.Param("id")); err != nil {
+			c.String(http.StatusInternalServerError, "Could not delete upload.")
+			return
+		}
+		writeResumableHeader(c)
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,32 @@
+package tus
+
+import (
+	"encoding/base64"
+	"strings"
+)
+
+// ParseMetadata decodes a tus Upload-Metadata header value: a
+// comma-separated list of "key base64(value)" pairs.
+func ParseMetadata(header string) map[string]string {
+	metadata := make(map[string]string)
+	if header == "" {
+		return metadata
+	}
+
+	for _, pair := range strings.Split(header, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), " ", 2)
+		if len(parts) == 0 || parts[0] == "" {
+			continue
+		}
+
+		key := parts[0]
+		value := ""
+		if len(parts) == 2 {
+			if decoded, err := base64.StdEncoding.DecodeString(parts[1]); err == nil {
+				value = string(decoded)
+			}
+		}
+		metadata[key] = value
+	}
+	return metadata
+}
@@ -0,0 +1,218 @@
+// Package tus implements enough of the tus.io 1.0.0 resumable upload
+// protocol (creation, termination and the core PATCH-based transfer) for
+// bearpush to accept large, flaky-network artifact uploads.
+package tus
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// reapInterval is how often a Store sweeps its directory for uploads past
+// their Expires, so an abandoned upload's .part file doesn't sit around
+// forever even if nothing ever calls Get on it again.
+const reapInterval = time.Hour
+
+// ResumableVersion is the tus protocol version this package implements.
+const ResumableVersion = "1.0.0"
+
+// Extensions lists the tus extensions bearpush supports, advertised on
+// OPTIONS requests.
+const Extensions = "creation,termination,expiration"
+
+// ErrOffsetMismatch is returned by WriteChunk when the caller's reported
+// Upload-Offset doesn't match what the store has on record; the client
+// should re-issue a HEAD to resynchronize.
+var ErrOffsetMismatch = errors.New("tus: upload offset mismatch")
+
+// ErrNotFound is returned when an upload id has no matching in-progress
+// upload (never created, already completed, or expired).
+var ErrNotFound = errors.New("tus: upload not found")
+
+// Upload is an in-progress, possibly partial upload. It's persisted as a
+// JSON sidecar next to its .part file so a restart doesn't orphan it.
+type Upload struct {
+	ID       string
+	Length   int64
+	Offset   int64
+	Metadata map[string]string
+	Expires  time.Time
+}
+
+// Store manages in-progress uploads as <Dir>/<uuid>.part files with a
+// <uuid>.json sidecar carrying the Upload metadata.
+type Store struct {
+	Dir string
+
+	mu sync.Mutex
+}
+
+// NewStore creates a Store rooted at dir, creating it if necessary.
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil && !os.IsExist(err) {
+		return nil, err
+	}
+	s := &Store{Dir: dir}
+	go s.reapLoop()
+	return s, nil
+}
+
+// reapLoop periodically removes uploads past their Expires. Expired
+// uploads are also caught lazily by Get, but this catches ones nothing
+// ever looks up again.
+func (s *Store) reapLoop() {
+	ticker := time.NewTicker(reapInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if n, err := s.ReapExpired(); err != nil {
+			zap.S().Warnf("tus: reaping expired uploads in %s: %s", s.Dir, err)
+		} else if n > 0 {
+			zap.S().Infof("tus: reaped %d expired upload(s) in %s", n, s.Dir)
+		}
+	}
+}
+
+// ReapExpired deletes every upload in the store whose Expires has passed,
+// and returns how many were removed.
+func (s *Store) ReapExpired() (int, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		id := strings.TrimSuffix(entry.Name(), ".json")
+		if _, err := s.Get(id); err == ErrNotFound {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *Store) partPath(id string) string    { return filepath.Join(s.Dir, id+".part") }
+func (s *Store) sidecarPath(id string) string { return filepath.Join(s.Dir, id+".json") }
+
+// PartPath returns the on-disk path of an upload's data file.
+func (s *Store) PartPath(id string) string { return s.partPath(id) }
+
+// Create starts tracking a new upload of the given total length.
+func (s *Store) Create(length int64, metadata map[string]string, ttl time.Duration) (*Upload, error) {
+	id := uuid.NewString()
+
+	f, err := os.Create(s.partPath(id))
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	u := &Upload{
+		ID:       id,
+		Length:   length,
+		Metadata: metadata,
+		Expires:  time.Now().Add(ttl),
+	}
+	if err := s.save(u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (s *Store) save(u *Upload) error {
+	data, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.sidecarPath(u.ID), data, 0640)
+}
+
+// Get loads the current state of an upload. An upload past its Expires is
+// treated the same as one that was never created: it's dropped from the
+// store and ErrNotFound is returned.
+func (s *Store) Get(id string) (*Upload, error) {
+	data, err := os.ReadFile(s.sidecarPath(id))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var u Upload
+	if err := json.Unmarshal(data, &u); err != nil {
+		return nil, err
+	}
+	if !u.Expires.IsZero() && time.Now().After(u.Expires) {
+		_ = s.Delete(id)
+		return nil, ErrNotFound
+	}
+	return &u, nil
+}
+
+// WriteChunk appends up to len(Upload.Length - offset) bytes read from r
+// to the upload's part file at offset, persisting the new Upload-Offset.
+//
+// u is only used to identify which upload to write to; its Offset may be
+// stale (the caller typically fetched it via Get before acquiring any
+// lock), so the authoritative offset is re-read from disk under mu before
+// the CAS check, closing the race where two concurrent PATCHes for the
+// same upload both pass a comparison against the same stale offset.
+func (s *Store) WriteChunk(u *Upload, offset int64, r io.Reader) (*Upload, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, err := s.Get(u.ID)
+	if err != nil {
+		return nil, err
+	}
+	u = current
+
+	if offset != u.Offset {
+		return nil, ErrOffsetMismatch
+	}
+
+	f, err := os.OpenFile(s.partPath(u.ID), os.O_WRONLY, 0640)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	n, err := io.Copy(f, io.LimitReader(r, u.Length-offset))
+	if err != nil {
+		return nil, err
+	}
+
+	u.Offset += n
+	if err := s.save(u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// Delete removes an upload's part file and sidecar. It does not error if
+// they're already gone.
+func (s *Store) Delete(id string) error {
+	_ = os.Remove(s.partPath(id))
+	if err := os.Remove(s.sidecarPath(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package tus
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteChunkRejectsStaleOffset(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore: %s", err)
+	}
+
+	upload, err := store.Create(10, nil, time.Hour)
+	if err != nil {
+		t.Fatalf("Create: %s", err)
+	}
+
+	// Simulate two requests that both fetched the upload before either
+	// wrote: both hold a copy with Offset == 0.
+	staleA := *upload
+	staleB := *upload
+
+	if _, err := store.WriteChunk(&staleA, 0, strings.NewReader("hello")); err != nil {
+		t.Fatalf("first WriteChunk: %s", err)
+	}
+
+	// staleB still thinks the offset is 0, but the store's authoritative
+	// state has since moved to 5; this must be rejected, not silently
+	// corrupt the file by seeking back to 0.
+	if _, err := store.WriteChunk(&staleB, 0, strings.NewReader("world")); err != ErrOffsetMismatch {
+		t.Fatalf("second WriteChunk with stale offset: got err=%v, want ErrOffsetMismatch", err)
+	}
+
+	current, err := store.Get(upload.ID)
+	if err != nil {
+		t.Fatalf("Get: %s", err)
+	}
+	if current.Offset != 5 {
+		t.Fatalf("Offset = %d, want 5 (stale write must not have applied)", current.Offset)
+	}
+}
+
+func TestGetRejectsExpiredUpload(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore: %s", err)
+	}
+
+	upload, err := store.Create(10, nil, -time.Minute)
+	if err != nil {
+		t.Fatalf("Create: %s", err)
+	}
+
+	if _, err := store.Get(upload.ID); err != ErrNotFound {
+		t.Fatalf("Get on expired upload: got err=%v, want ErrNotFound", err)
+	}
+	if _, err := os.Stat(store.PartPath(upload.ID)); !os.IsNotExist(err) {
+		t.Fatalf("part file for expired upload still exists after Get")
+	}
+}
+
+func TestReapExpiredRemovesOnlyPastExpires(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore: %s", err)
+	}
+
+	expired, err := store.Create(10, nil, -time.Minute)
+	if err != nil {
+		t.Fatalf("Create expired: %s", err)
+	}
+	live, err := store.Create(10, nil, time.Hour)
+	if err != nil {
+		t.Fatalf("Create live: %s", err)
+	}
+
+	n, err := store.ReapExpired()
+	if err != nil {
+		t.Fatalf("ReapExpired: %s", err)
+	}
+	if n != 1 {
+		t.Fatalf("ReapExpired removed %d uploads, want 1", n)
+	}
+
+	if _, err := store.Get(expired.ID); err != ErrNotFound {
+		t.Fatalf("Get on reaped upload: got err=%v, want ErrNotFound", err)
+	}
+	if _, err := store.Get(live.ID); err != nil {
+		t.Fatalf("Get on live upload: %s", err)
+	}
+}
+
+func TestWriteChunkAppendsSequentially(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore: %s", err)
+	}
+
+	upload, err := store.Create(10, nil, time.Hour)
+	if err != nil {
+		t.Fatalf("Create: %s", err)
+	}
+
+	upload, err = store.WriteChunk(upload, 0, strings.NewReader("hello"))
+	if err != nil {
+		t.Fatalf("WriteChunk 1: %s", err)
+	}
+	if upload.Offset != 5 {
+		t.Fatalf("Offset after 1st chunk = %d, want 5", upload.Offset)
+	}
+
+	upload, err = store.WriteChunk(upload, 5, strings.NewReader("world"))
+	if err != nil {
+		t.Fatalf("WriteChunk 2: %s", err)
+	}
+	if upload.Offset != 10 {
+		t.Fatalf("Offset after 2nd chunk = %d, want 10", upload.Offset)
+	}
+}